@@ -0,0 +1,170 @@
+package core
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/fsouza/go-dockerclient"
+)
+
+type certBundle struct {
+	cert, key, ca []byte
+}
+
+var certCache = struct {
+	mu sync.Mutex
+	m  map[string]*certBundle
+}{m: make(map[string]*certBundle)}
+
+func loadCertBundle(path string) (*certBundle, error) {
+	certCache.mu.Lock()
+	defer certCache.mu.Unlock()
+
+	if b, ok := certCache.m[path]; ok {
+		return b, nil
+	}
+
+	cert, err := ioutil.ReadFile(path + "/cert.pem")
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ioutil.ReadFile(path + "/key.pem")
+	if err != nil {
+		return nil, err
+	}
+
+	ca, err := ioutil.ReadFile(path + "/ca.pem")
+	if err != nil {
+		return nil, err
+	}
+
+	b := &certBundle{cert: cert, key: key, ca: ca}
+	certCache.m[path] = b
+	return b, nil
+}
+
+func newPooledDocker(endPoint string, env *Environment) (*Docker, error) {
+	if env == nil || env.CertPath == "" {
+		return NewDocker(endPoint, env)
+	}
+
+	b, err := loadCertBundle(env.CertPath)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := docker.NewTLSClientFromBytes(endPoint, b.cert, b.key, b.ca)
+	if err != nil {
+		return nil, err
+	}
+
+	Debug("Connected to docker", "end-point", endPoint)
+	return &Docker{client: c, endPoint: endPoint, env: env}, nil
+}
+
+type MultiError struct {
+	Errors map[string]error
+}
+
+func (e *MultiError) Error() string {
+	parts := make([]string, 0, len(e.Errors))
+	for endPoint, err := range e.Errors {
+		parts = append(parts, fmt.Sprintf("%s: %s", endPoint, err))
+	}
+	sort.Strings(parts)
+
+	return strings.Join(parts, "; ")
+}
+
+type Cluster struct {
+	Dockers []*Docker
+
+	MaxUnavailable int
+	MaxSurge       int
+}
+
+func NewCluster(endPoints []string, env *Environment) (*Cluster, error) {
+	c := &Cluster{}
+	for _, ep := range endPoints {
+		d, err := newPooledDocker(ep, env)
+		if err != nil {
+			return nil, err
+		}
+
+		c.Dockers = append(c.Dockers, d)
+	}
+
+	return c, nil
+}
+
+func (c *Cluster) Deploy(p *Project, rev Revision, dockerfile *Dockerfile, output io.Writer, strategy DeployStrategy) error {
+	batchSize := c.MaxSurge
+	if batchSize <= 0 {
+		batchSize = len(c.Dockers)
+	}
+
+	errs := &MultiError{Errors: map[string]error{}}
+	var mu sync.Mutex
+
+	var writeMu sync.Mutex
+
+	for i := 0; i < len(c.Dockers); i += batchSize {
+		end := i + batchSize
+		if end > len(c.Dockers) {
+			end = len(c.Dockers)
+		}
+
+		var wg sync.WaitGroup
+		var batchFailed int
+
+		for _, d := range c.Dockers[i:end] {
+			wg.Add(1)
+			go func(d *Docker) {
+				defer wg.Done()
+
+				w := &prefixWriter{w: output, mu: &writeMu, prefix: fmt.Sprintf("[%s] ", d.endPoint)}
+				if err := d.DeployWithStrategy(p, rev, dockerfile, w, false, strategy); err != nil {
+					mu.Lock()
+					errs.Errors[d.endPoint] = err
+					batchFailed++
+					mu.Unlock()
+				}
+			}(d)
+		}
+		wg.Wait()
+
+		if batchFailed > c.MaxUnavailable {
+			return errs
+		}
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// mu must be shared across every prefixWriter writing to the same
+// underlying writer.
+type prefixWriter struct {
+	w      io.Writer
+	mu     *sync.Mutex
+	prefix string
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if _, err := w.w.Write([]byte(w.prefix)); err != nil {
+		return 0, err
+	}
+
+	return w.w.Write(p)
+}
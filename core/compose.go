@@ -0,0 +1,343 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+
+	"github.com/fsouza/go-dockerclient"
+	"gopkg.in/yaml.v2"
+)
+
+type ComposeService struct {
+	Name        string   `yaml:"-"`
+	Image       string   `yaml:"image"`
+	Build       string   `yaml:"build"`
+	Ports       []string `yaml:"ports"`
+	Volumes     []string `yaml:"volumes"`
+	VolumesFrom []string `yaml:"volumes_from"`
+	Environment []string `yaml:"environment"`
+	Links       []string `yaml:"links"`
+	DependsOn   []string `yaml:"depends_on"`
+	History     int      `yaml:"history"`
+}
+
+type ComposeProject struct {
+	Name     string
+	Network  string
+	Services map[string]*ComposeService
+}
+
+type composeFile struct {
+	Services map[string]*ComposeService `yaml:"services"`
+}
+
+func LoadComposeProject(name, path string) (*ComposeProject, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var f composeFile
+	if err := yaml.Unmarshal(raw, &f); err != nil {
+		return nil, err
+	}
+
+	cp := &ComposeProject{
+		Name:     name,
+		Network:  fmt.Sprintf("%s-net", name),
+		Services: f.Services,
+	}
+
+	for name, svc := range cp.Services {
+		svc.Name = name
+	}
+
+	return cp, nil
+}
+
+func (cp *ComposeProject) serviceOrder() ([]string, error) {
+	var order []string
+	state := make(map[string]int) // 0 unvisited, 1 visiting, 2 done
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 2:
+			return nil
+		case 1:
+			return fmt.Errorf("circular dependency involving service %q", name)
+		}
+
+		svc, ok := cp.Services[name]
+		if !ok {
+			return fmt.Errorf("unknown service %q", name)
+		}
+
+		state[name] = 1
+		for _, dep := range svc.DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+
+		state[name] = 2
+		order = append(order, name)
+		return nil
+	}
+
+	for name := range cp.Services {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+func (cp *ComposeProject) containerName(service string) string {
+	return fmt.Sprintf("%s-%s", cp.Name, service)
+}
+
+func (d *Docker) DeployCompose(cp *ComposeProject, output io.Writer) error {
+	Debug("Deploying compose project", "project", cp.Name, "end-point", d.endPoint)
+
+	if err := d.ensureComposeNetwork(cp); err != nil {
+		return err
+	}
+
+	order, err := cp.serviceOrder()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range order {
+		svc := cp.Services[name]
+		if err := d.deployComposeService(cp, svc, output); err != nil {
+			return err
+		}
+	}
+
+	return d.RestartComposeGraph(cp)
+}
+
+func (d *Docker) ensureComposeNetwork(cp *ComposeProject) error {
+	networks, err := d.client.ListNetworks()
+	if err != nil {
+		return err
+	}
+
+	for _, n := range networks {
+		if n.Name == cp.Network {
+			return nil
+		}
+	}
+
+	Debug("Creating compose network", "project", cp.Name, "network", cp.Network, "end-point", d.endPoint)
+	_, err = d.client.CreateNetwork(docker.CreateNetworkOptions{Name: cp.Network})
+	return err
+}
+
+func (d *Docker) deployComposeService(cp *ComposeProject, svc *ComposeService, output io.Writer) error {
+	image := svc.Image
+	if svc.Build != "" {
+		dockerfile, err := LoadDockerfile(svc.Build)
+		if err != nil {
+			return err
+		}
+
+		built := ImageID(fmt.Sprintf("%s-%s:latest", cp.Name, svc.Name))
+		if err := d.buildComposeImage(built, cp, svc, dockerfile, output); err != nil {
+			return err
+		}
+		image = string(built)
+	}
+
+	if err := d.cleanComposeContainer(cp, svc); err != nil {
+		return err
+	}
+
+	if err := d.cleanComposeImages(cp, svc.Name, svc.History); err != nil {
+		return err
+	}
+
+	name := cp.containerName(svc.Name)
+	c, err := d.client.CreateContainer(docker.CreateContainerOptions{
+		Name: name,
+		Config: &docker.Config{
+			Image: image,
+			Env:   svc.Environment,
+			Labels: map[string]string{
+				"project": cp.Name,
+				"service": svc.Name,
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	ports, err := d.formatPorts(svc.Ports)
+	if err != nil {
+		return err
+	}
+
+	if err := d.client.StartContainer(c.ID, &docker.HostConfig{
+		PortBindings: ports,
+		Links:        svc.Links,
+		VolumesFrom:  svc.VolumesFrom,
+		Binds:        svc.Volumes,
+	}); err != nil {
+		return err
+	}
+
+	return d.client.ConnectNetwork(cp.Network, docker.NetworkConnectionOptions{Container: c.ID})
+}
+
+func (d *Docker) buildComposeImage(image ImageID, cp *ComposeProject, svc *ComposeService, dockerfile *Dockerfile, output io.Writer) error {
+	input := bytes.NewBuffer(nil)
+	if err := d.buildTar(nil, dockerfile, input); err != nil {
+		return err
+	}
+
+	return d.client.BuildImage(docker.BuildImageOptions{
+		Name:         string(image),
+		InputStream:  input,
+		OutputStream: output,
+		Labels: map[string]string{
+			"project": cp.Name,
+			"service": svc.Name,
+		},
+	})
+}
+
+func (d *Docker) cleanComposeContainers(cp *ComposeProject, service string) error {
+	l, err := d.listComposeContainers(cp, service)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range l {
+		if c.IsRunning() {
+			if err := d.killContainer(c); err != nil {
+				return err
+			}
+		}
+
+		if err := d.removeContainer(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Docker) cleanComposeContainer(cp *ComposeProject, svc *ComposeService) error {
+	return d.cleanComposeContainers(cp, svc.Name)
+}
+
+func (d *Docker) cleanComposeImages(cp *ComposeProject, service string, keep int) error {
+	if keep < 0 {
+		keep = 0
+	}
+
+	l, err := d.listComposeImages(cp, service)
+	if err != nil {
+		return err
+	}
+
+	count := len(l)
+	if count < keep {
+		return nil
+	}
+
+	Debug("Removing old compose images", "project", cp.Name, "service", service, "count", count-keep, "end-point", d.endPoint)
+	for _, i := range l[:count-keep] {
+		if err := d.removeImage(i); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Docker) listComposeContainers(cp *ComposeProject, service string) ([]*Container, error) {
+	l, err := d.client.ListContainers(docker.ListContainersOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var r []*Container
+	for _, apiC := range l {
+		if apiC.Labels["project"] != cp.Name {
+			continue
+		}
+		if service != "" && apiC.Labels["service"] != service {
+			continue
+		}
+
+		r = append(r, &Container{
+			Image:          ImageID(apiC.Image),
+			APIContainers:  apiC,
+			DockerEndPoint: d.endPoint,
+		})
+	}
+
+	return r, nil
+}
+
+func (d *Docker) listComposeImages(cp *ComposeProject, service string) ([]*Image, error) {
+	l, err := d.client.ListImages(docker.ListImagesOptions{All: true})
+	if err != nil {
+		return nil, err
+	}
+
+	var r []*Image
+	for _, apiI := range l {
+		if apiI.Labels["project"] != cp.Name {
+			continue
+		}
+		if service != "" && apiI.Labels["service"] != service {
+			continue
+		}
+
+		r = append(r, &Image{APIImages: apiI, DockerEndPoint: d.endPoint})
+	}
+
+	sort.Sort(ImagesByCreated(r))
+
+	return r, nil
+}
+
+func (d *Docker) RestartComposeGraph(cp *ComposeProject) error {
+	order, err := cp.serviceOrder()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range order {
+		containers, err := d.listComposeContainers(cp, name)
+		if err != nil {
+			return err
+		}
+
+		for _, c := range containers {
+			if !c.IsRunning() {
+				continue
+			}
+
+			Info("Restarting compose service", "project", cp.Name, "service", name, "container", c.GetShortID())
+			if err := d.killContainer(c); err != nil {
+				return err
+			}
+
+			if err := d.client.StartContainer(c.ID, nil); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -45,7 +45,7 @@ func NewDocker(endPoint string, env *Environment) (*Docker, error) {
 	return &Docker{client: c, endPoint: endPoint, env: env}, nil
 }
 
-func (d *Docker) Deploy(p *Project, rev Revision, dockerfile *Dockerfile, output io.Writer, force bool) error {
+func (d *Docker) Deploy(p *Project, rev Revision, dockerfile *Dockerfile, output io.Writer, force, push bool) error {
 	Debug("Deploying dockerfile", "project", p, "revision", rev, "end-point", d.endPoint)
 
 	if err := d.cleanImages(p); err != nil {
@@ -56,6 +56,12 @@ func (d *Docker) Deploy(p *Project, rev Revision, dockerfile *Dockerfile, output
 		return err
 	}
 
+	if push {
+		if err := d.PushImage(p, rev, output); err != nil {
+			return err
+		}
+	}
+
 	if err := d.cleanContainers(p); err != nil {
 		return err
 	}
@@ -211,6 +217,10 @@ func (d *Docker) BuildImage(
 ) error {
 	Debug("Building image", "project", p, "revision", rev, "end-point", d.endPoint)
 
+	if err := d.pullBaseImage(p, dockerfile, output); err != nil {
+		return err
+	}
+
 	input := bytes.NewBuffer(nil)
 	if err := d.buildTar(p, dockerfile, input); err != nil {
 		return err
@@ -232,6 +242,71 @@ func (d *Docker) BuildImage(
 	return d.tagImage(image)
 }
 
+func (d *Docker) pullBaseImage(p *Project, dockerfile *Dockerfile, output io.Writer) error {
+	from := dockerfile.From()
+	if from == "" || from == "scratch" {
+		return nil
+	}
+
+	if _, err := d.client.InspectImage(from); err == nil {
+		return nil
+	}
+
+	return d.PullImage(from, output)
+}
+
+func (d *Docker) PushImage(p *Project, rev Revision, output io.Writer) error {
+	image := d.getImageName(p, rev)
+
+	for _, tag := range []string{LatestTag, image.GetRevisionString()} {
+		Debug("Pushing image", "project", p, "tag", tag, "end-point", d.endPoint)
+
+		opts := docker.PushImageOptions{
+			Name:         image.GetProjectString(),
+			Tag:          tag,
+			Registry:     p.Registry,
+			OutputStream: output,
+		}
+
+		if err := d.client.PushImage(opts, d.authConfiguration()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *Docker) PullImage(ref string, output io.Writer) error {
+	Debug("Pulling image", "ref", ref, "end-point", d.endPoint)
+
+	repository, tag := docker.ParseRepositoryTag(ref)
+	if tag == "" {
+		tag = LatestTag
+	}
+
+	opts := docker.PullImageOptions{
+		Repository:   repository,
+		Tag:          tag,
+		OutputStream: output,
+	}
+
+	return d.client.PullImage(opts, d.authConfiguration())
+}
+
+func (d *Docker) authConfiguration() docker.AuthConfiguration {
+	if d.env == nil || d.env.Registry == nil {
+		return docker.AuthConfiguration{}
+	}
+
+	r := d.env.Registry
+	return docker.AuthConfiguration{
+		ServerAddress: r.Address,
+		Username:      r.Username,
+		Password:      r.Password,
+		Email:         r.Email,
+	}
+}
+
 func (d *Docker) tagImage(image ImageID) error {
 	for _, tag := range []string{LatestTag, image.GetRevisionString()} {
 		err := d.client.TagImage(string(image), docker.TagImageOptions{
@@ -266,9 +341,23 @@ func (d *Docker) Run(p *Project, rev Revision) error {
 		return err
 	}
 
+	if err := d.WaitHealthy(c, p.HealthCheckTimeout()); err != nil {
+		return err
+	}
+
 	return d.restartLinkedContainers(p)
 }
 
+const defaultHealthCheckTimeout = 60 * time.Second
+
+func (p *Project) HealthCheckTimeout() time.Duration {
+	if p.Healthcheck == nil || p.Healthcheck.StartPeriod == 0 {
+		return defaultHealthCheckTimeout
+	}
+
+	return p.Healthcheck.StartPeriod
+}
+
 func (d *Docker) getImageName(p *Project, rev Revision) ImageID {
 	c := rev.String()
 	if p.UseShortRevisions {
@@ -279,10 +368,18 @@ func (d *Docker) getImageName(p *Project, rev Revision) ImageID {
 }
 
 func (d *Docker) createContainer(p *Project, image ImageID) (*Container, error) {
+	env := d.formatEnv(p.Environment)
+
 	c, err := d.client.CreateContainer(docker.CreateContainerOptions{
 		Name: p.Name,
 		Config: &docker.Config{
-			Image: string(image),
+			Image:       string(image),
+			Env:         env,
+			Labels:      p.Labels,
+			User:        p.User,
+			WorkingDir:  p.WorkingDir,
+			Entrypoint:  p.Entrypoint,
+			Healthcheck: p.Healthcheck.toDockerConfig(),
 		},
 	})
 
@@ -310,9 +407,84 @@ func (d *Docker) startContainer(p *Project, c *Container) error {
 		Links:         d.formatLinks(p.Links),
 		VolumesFrom:   p.VolumesFrom,
 		Binds:         p.Binds,
+		Tmpfs:         p.Tmpfs,
+		CapAdd:        p.CapAdd,
+		CapDrop:       p.CapDrop,
+		SecurityOpt:   p.SecurityOpt,
+		Ulimits:       p.Ulimits,
+		CPUShares:     p.CPUShares,
+		CPUQuota:      p.CPUQuota,
+		Memory:        p.Memory,
+		MemorySwap:    p.MemorySwap,
 	})
 }
 
+func (d *Docker) formatEnv(env []string) []string {
+	var r []string
+	for _, e := range env {
+		parts := strings.SplitN(e, "@", 2)
+		if len(parts) == 2 && d.env != nil && d.env.Name != parts[1] {
+			continue
+		}
+
+		r = append(r, parts[0])
+	}
+
+	return r
+}
+
+func (d *Docker) WaitHealthy(c *Container, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+
+	for {
+		info, err := d.client.InspectContainer(c.ID)
+		if err != nil {
+			return err
+		}
+
+		if info.State.Health.Status == "" {
+			// No HEALTHCHECK configured: return at once, without checking
+			// whether the container is running.
+			return nil
+		}
+
+		switch info.State.Health.Status {
+		case "healthy":
+			return nil
+		case "unhealthy":
+			return fmt.Errorf("container %s is unhealthy", c.GetShortID())
+		}
+
+		if time.Now().After(deadline) {
+			return fmt.Errorf("container %s did not become healthy within %s", c.GetShortID(), timeout)
+		}
+
+		time.Sleep(time.Second)
+	}
+}
+
+type Healthcheck struct {
+	Test        []string
+	Interval    time.Duration
+	Timeout     time.Duration
+	StartPeriod time.Duration
+	Retries     int
+}
+
+func (h *Healthcheck) toDockerConfig() *docker.HealthConfig {
+	if h == nil {
+		return nil
+	}
+
+	return &docker.HealthConfig{
+		Test:        h.Test,
+		Interval:    h.Interval,
+		Timeout:     h.Timeout,
+		StartPeriod: h.StartPeriod,
+		Retries:     h.Retries,
+	}
+}
+
 func (d *Docker) formatLinks(links map[string]*Link) []string {
 	var r []string
 	for _, link := range links {
@@ -455,6 +627,45 @@ func (d *Docker) restartLinkedContainers(p *Project) error {
 	return nil
 }
 
+type LogOptions struct {
+	Tail       string
+	Since      int64
+	Follow     bool
+	Timestamps bool
+}
+
+func (d *Docker) Logs(c *Container, opts LogOptions, stdout, stderr io.Writer) error {
+	Debug("Streaming container logs", "container", c.GetShortID(), "end-point", d.endPoint)
+
+	return d.client.Logs(docker.LogsOptions{
+		Container:    c.ID,
+		OutputStream: stdout,
+		ErrorStream:  stderr,
+		Stdout:       true,
+		Stderr:       true,
+		Tail:         opts.Tail,
+		Since:        opts.Since,
+		Follow:       opts.Follow,
+		Timestamps:   opts.Timestamps,
+	})
+}
+
+func (d *Docker) Attach(c *Container, in io.Reader, out io.Writer) error {
+	Debug("Attaching to container", "container", c.GetShortID(), "end-point", d.endPoint)
+
+	return d.client.AttachToContainer(docker.AttachToContainerOptions{
+		Container:    c.ID,
+		InputStream:  in,
+		OutputStream: out,
+		ErrorStream:  out,
+		Stdin:        true,
+		Stdout:       true,
+		Stderr:       true,
+		Stream:       true,
+		RawTerminal:  true,
+	})
+}
+
 func (d *Docker) restartContainer(p *Project, c *Container) error {
 	if !c.IsRunning() {
 		return nil
@@ -0,0 +1,158 @@
+package core
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/fsouza/go-dockerclient"
+)
+
+type DeployStrategy interface {
+	Deploy(d *Docker, p *Project, rev Revision, dockerfile *Dockerfile, output io.Writer, push bool) error
+}
+
+func (d *Docker) DeployWithStrategy(p *Project, rev Revision, dockerfile *Dockerfile, output io.Writer, push bool, strategy DeployStrategy) error {
+	if strategy == nil {
+		strategy = Recreate{}
+	}
+
+	return strategy.Deploy(d, p, rev, dockerfile, output, push)
+}
+
+type Recreate struct{}
+
+func (Recreate) Deploy(d *Docker, p *Project, rev Revision, dockerfile *Dockerfile, output io.Writer, push bool) error {
+	return d.Deploy(p, rev, dockerfile, output, false, push)
+}
+
+type BlueGreen struct{}
+
+func (BlueGreen) Deploy(d *Docker, p *Project, rev Revision, dockerfile *Dockerfile, output io.Writer, push bool) error {
+	prevImage, _ := d.client.InspectImage(fmt.Sprintf("%s:%s", p.Name, LatestTag))
+
+	if err := d.BuildImage(p, rev, dockerfile, output); err != nil {
+		return err
+	}
+
+	if push {
+		if err := d.PushImage(p, rev, output); err != nil {
+			return err
+		}
+	}
+
+	image := d.getImageName(p, rev)
+	altName := fmt.Sprintf("%s-%s", p.Name, rev.GetShort())
+
+	newC, err := d.createContainerNamed(altName, p, image)
+	if err != nil {
+		d.rollback(p, nil, nil, prevImage)
+		return err
+	}
+
+	if err := d.client.StartContainer(newC.ID, &docker.HostConfig{RestartPolicy: docker.NeverRestart()}); err != nil {
+		d.rollback(p, newC, nil, prevImage)
+		return err
+	}
+
+	if err := d.WaitHealthy(newC, p.HealthCheckTimeout()); err != nil {
+		d.rollback(p, newC, nil, prevImage)
+		return err
+	}
+
+	old, err := d.ListContainers(p)
+	if err != nil {
+		d.rollback(p, newC, nil, prevImage)
+		return err
+	}
+
+	Info("Swapping to new container", "project", p, "revision", rev.GetShort(), "container", newC.GetShortID(), "end-point", d.endPoint)
+	if err := d.swap(p, newC, old); err != nil {
+		d.rollback(p, newC, old, prevImage)
+		return err
+	}
+
+	for _, oc := range old {
+		d.removeContainer(oc)
+	}
+
+	return nil
+}
+
+func (d *Docker) createContainerNamed(name string, p *Project, image ImageID) (*Container, error) {
+	env := d.formatEnv(p.Environment)
+
+	c, err := d.client.CreateContainer(docker.CreateContainerOptions{
+		Name: name,
+		Config: &docker.Config{
+			Image:       string(image),
+			Env:         env,
+			Labels:      p.Labels,
+			User:        p.User,
+			WorkingDir:  p.WorkingDir,
+			Entrypoint:  p.Entrypoint,
+			Healthcheck: p.Healthcheck.toDockerConfig(),
+		},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &Container{Image: image, APIContainers: docker.APIContainers{ID: c.ID}}, nil
+}
+
+func oldContainerName(p *Project) string {
+	return p.Name + "-old"
+}
+
+func (d *Docker) swap(p *Project, newC *Container, old []*Container) error {
+	for _, oc := range old {
+		if oc.IsRunning() {
+			if err := d.killContainer(oc); err != nil {
+				return err
+			}
+		}
+
+		if err := d.client.RenameContainer(docker.RenameContainerOptions{ID: oc.ID, Name: oldContainerName(p)}); err != nil {
+			return err
+		}
+	}
+
+	if err := d.client.StopContainer(newC.ID, 10); err != nil {
+		return err
+	}
+
+	if err := d.client.RenameContainer(docker.RenameContainerOptions{ID: newC.ID, Name: p.Name}); err != nil {
+		return err
+	}
+
+	return d.startContainer(p, newC)
+}
+
+// old is nil when the failure happened before swap touched the old containers.
+func (d *Docker) rollback(p *Project, newC *Container, old []*Container, prevImage *docker.Image) {
+	Error("Blue/green deploy failed, rolling back", "project", p)
+
+	if newC != nil {
+		d.killContainer(newC)
+		d.removeContainer(newC)
+	}
+
+	for _, oc := range old {
+		if err := d.client.RenameContainer(docker.RenameContainerOptions{ID: oc.ID, Name: p.Name}); err != nil {
+			Error(err.Error(), "project", p, "container", oc.GetShortID())
+		}
+
+		if err := d.client.StartContainer(oc.ID, nil); err != nil {
+			Error(err.Error(), "project", p, "container", oc.GetShortID())
+		}
+	}
+
+	if prevImage == nil {
+		return
+	}
+
+	if err := d.client.TagImage(prevImage.ID, docker.TagImageOptions{Force: true, Repo: p.Name, Tag: LatestTag}); err != nil {
+		Error(err.Error(), "project", p, "image", prevImage.ID)
+	}
+}
@@ -0,0 +1,85 @@
+package web
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/gfk-dev/dockership/core"
+	"github.com/gorilla/websocket"
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+}
+
+// gorilla/websocket allows only one concurrent writer per connection, so
+// every wsWriter for a given conn must share the same mu.
+type wsWriter struct {
+	conn *websocket.Conn
+	mu   *sync.Mutex
+	tag  string
+}
+
+func (w *wsWriter) Write(p []byte) (int, error) {
+	msg := append([]byte(w.tag+": "), p...)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+func DeployStreamHandler(d *core.Docker, p *core.Project, rev core.Revision, dockerfile *core.Dockerfile) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			core.Error(err.Error(), "handler", "DeployStreamHandler")
+			return
+		}
+		defer conn.Close()
+
+		var writeMu sync.Mutex
+
+		build := &wsWriter{conn: conn, mu: &writeMu, tag: "build"}
+		if err := d.Deploy(p, rev, dockerfile, build, false, false); err != nil {
+			build.Write([]byte(fmt.Sprintf("error: %s", err)))
+			return
+		}
+
+		streamContainerLogs(d, p, conn, &writeMu)
+
+		// The goroutines above exit once a write on conn starts failing, so
+		// keep the connection open until the client goes away.
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func streamContainerLogs(d *core.Docker, p *core.Project, conn *websocket.Conn, mu *sync.Mutex) {
+	containers, err := d.ListContainers(p)
+	if err != nil {
+		core.Error(err.Error(), "handler", "DeployStreamHandler")
+		return
+	}
+
+	for _, c := range containers {
+		out := &wsWriter{conn: conn, mu: mu, tag: fmt.Sprintf("logs:%s", c.GetShortID())}
+		opts := core.LogOptions{Tail: "all", Follow: true}
+
+		go func(c *core.Container, out io.Writer) {
+			if err := d.Logs(c, opts, out, out); err != nil {
+				core.Error(err.Error(), "handler", "DeployStreamHandler", "container", c.GetShortID())
+			}
+		}(c, out)
+	}
+}